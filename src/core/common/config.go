@@ -12,13 +12,18 @@ import (
 type ConfigReq struct {
 	Name  string `json:"name" example:"SPIDER_REST_URL"`
 	Value string `json:"value" example:"http://localhost:1024/spider"`
+	// Sensitive marks Value as a credential (e.g. DB_PASSWORD). Sensitive values are encrypted at
+	// rest via EncryptSensitive and are always redacted to RedactedValue in GetConfig/ListConfig;
+	// in-process code reads the plaintext through ResolveSecret.
+	Sensitive bool `json:"sensitive" example:"false"`
 }
 
 // swagger:response ConfigInfo
 type ConfigInfo struct {
-	Id    string `json:"id" example:"SPIDER_REST_URL"`
-	Name  string `json:"name" example:"SPIDER_REST_URL"`
-	Value string `json:"value" example:"http://localhost:1024/spider"`
+	Id        string `json:"id" example:"SPIDER_REST_URL"`
+	Name      string `json:"name" example:"SPIDER_REST_URL"`
+	Value     string `json:"value" example:"http://localhost:1024/spider"`
+	Sensitive bool   `json:"sensitive" example:"false"`
 }
 
 func UpdateConfig(u *ConfigReq) (ConfigInfo, error) {
@@ -26,8 +31,18 @@ func UpdateConfig(u *ConfigReq) (ConfigInfo, error) {
 	content := ConfigInfo{}
 	content.Id = u.Name
 	content.Name = u.Name
+	content.Sensitive = u.Sensitive
 	content.Value = u.Value
 
+	if content.Sensitive {
+		encrypted, err := EncryptSensitive(u.Value)
+		if err != nil {
+			CBLog.Error(err)
+			return content, err
+		}
+		content.Value = encrypted
+	}
+
 	key := "/config/" + content.Id
 	//mapA := map[string]string{"name": content.Name, "description": content.Description}
 	val, _ := json.Marshal(content)
@@ -38,12 +53,25 @@ func UpdateConfig(u *ConfigReq) (ConfigInfo, error) {
 	}
 	keyValue, _ := CBStore.Get(string(key))
 	fmt.Println("UpdateConfig(); ===========================")
-	fmt.Println("UpdateConfig(); Key: " + keyValue.Key + "\nValue: " + keyValue.Value)
+	fmt.Println("UpdateConfig(); Key: " + keyValue.Key + "\nValue: " + redactForLog(content))
 	fmt.Println("UpdateConfig(); ===========================")
 
 	UpdateEnv(content.Id)
 
-	return content, nil
+	response := content
+	if content.Sensitive {
+		response.Value = RedactedValue
+	}
+	return response, nil
+}
+
+// redactForLog returns info.Value, or RedactedValue if info.Sensitive, for use in fmt.Println/log
+// lines so credentials never hit stdout or a log file.
+func redactForLog(info ConfigInfo) string {
+	if info.Sensitive {
+		return RedactedValue
+	}
+	return info.Value
 }
 
 func UpdateEnv(id string) error {
@@ -57,7 +85,9 @@ func UpdateEnv(id string) error {
 		common.DB_PASSWORD = common.NVL(os.Getenv("DB_PASSWORD"), "cb_tumblebug")
 	*/
 
-	configInfo, err := GetConfig(id)
+	// UpdateEnv is internal/trusted code (it feeds process-wide config like DB_PASSWORD), so it
+	// reveals the plaintext value regardless of Sensitive.
+	configInfo, err := getConfigReveal(id, true)
 	if err != nil {
 		//CBLog.Error(err)
 		return err
@@ -81,10 +111,16 @@ func UpdateEnv(id string) error {
 		fmt.Println("<DB_USER> " + DB_USER)
 	case StrDB_PASSWORD:
 		DB_PASSWORD = configInfo.Value
-		fmt.Println("<DB_PASSWORD> " + DB_PASSWORD)
+		fmt.Println("<DB_PASSWORD> " + redactForLog(configInfo))
 	case StrAUTOCONTROL_DURATION_MS:
 		AUTOCONTROL_DURATION_MS = configInfo.Value
 		fmt.Println("<AUTOCONTROL_DURATION_MS> " + AUTOCONTROL_DURATION_MS)
+	case StrINFOSTORE_DRIVER:
+		INFOSTORE_DRIVER = configInfo.Value
+		fmt.Println("<INFOSTORE_DRIVER> " + INFOSTORE_DRIVER)
+	case StrSECRETS_BACKEND:
+		SECRETS_BACKEND = configInfo.Value
+		fmt.Println("<SECRETS_BACKEND> " + SECRETS_BACKEND)
 	default:
 
 	}
@@ -92,7 +128,18 @@ func UpdateEnv(id string) error {
 	return nil
 }
 
+// GetConfig returns the ConfigInfo stored under id. A Sensitive value is decrypted internally and
+// then redacted to RedactedValue in the returned struct; trusted in-process callers that need the
+// plaintext use ResolveSecret (see getConfigReveal).
 func GetConfig(id string) (ConfigInfo, error) {
+	return getConfigReveal(id, false)
+}
+
+// getConfigReveal is the internal accessor behind GetConfig: reveal controls whether a Sensitive
+// value's plaintext is left in the returned struct instead of being redacted to RedactedValue.
+// reveal is only meant to be set by trusted in-process callers (UpdateEnv, ResolveSecret), never
+// forwarded from an untrusted API request.
+func getConfigReveal(id string, reveal bool) (ConfigInfo, error) {
 
 	res := ConfigInfo{}
 
@@ -120,17 +167,40 @@ func GetConfig(id string) (ConfigInfo, error) {
 		return res, err
 	}
 
-	fmt.Println("<" + keyValue.Key + "> " + keyValue.Value)
-	//fmt.Println("===============================================")
-
 	err = json.Unmarshal([]byte(keyValue.Value), &res)
 	if err != nil {
 		CBLog.Error(err)
 		return res, err
 	}
+
+	if res.Sensitive {
+		plaintext, err := DecryptSensitive(res.Value)
+		if err != nil {
+			CBLog.Error(err)
+			return res, err
+		}
+		res.Value = plaintext
+	}
+
+	fmt.Println("<" + keyValue.Key + "> " + redactForLog(res))
+	//fmt.Println("===============================================")
+
+	if res.Sensitive && !reveal {
+		res.Value = RedactedValue
+	}
 	return res, nil
 }
 
+// ResolveSecret returns the plaintext value of config id for internal callers that need the
+// actual credential (e.g. opening a DB connection), bypassing the GetConfig redaction.
+func ResolveSecret(id string) (string, error) {
+	info, err := getConfigReveal(id, true)
+	if err != nil {
+		return "", err
+	}
+	return info.Value, nil
+}
+
 func ListConfig() ([]ConfigInfo, error) {
 	fmt.Println("[List config]")
 	key := "/config"
@@ -152,6 +222,9 @@ func ListConfig() ([]ConfigInfo, error) {
 				CBLog.Error(err)
 				return nil, err
 			}
+			if tempObj.Sensitive {
+				tempObj.Value = RedactedValue
+			}
 			res = append(res, tempObj)
 		}
 		return res, nil
@@ -160,6 +233,65 @@ func ListConfig() ([]ConfigInfo, error) {
 	return nil, nil // When err == nil && keyValue == nil
 }
 
+// MigrateSensitiveConfig encrypts any ConfigInfo entries that are marked Sensitive but still hold
+// a plaintext value, and entries whose Name/Id is a known-sensitive key (e.g. DB_PASSWORD) but
+// were stored before the Sensitive field existed. Safe to call on every startup: already-encrypted
+// entries are left untouched.
+func MigrateSensitiveConfig() error {
+	key := "/config"
+	keyValue, err := CBStore.GetList(key, true)
+	keyValue = cbstore_utils.GetChildList(keyValue, key)
+	if err != nil {
+		CBLog.Error(err)
+		return err
+	}
+
+	for _, v := range keyValue {
+		info := ConfigInfo{}
+		if err := json.Unmarshal([]byte(v.Value), &info); err != nil {
+			CBLog.Error(err)
+			continue
+		}
+
+		needsEncryption := info.Value != "" && (info.Sensitive || info.Id == StrDB_PASSWORD)
+		if !needsEncryption {
+			continue
+		}
+		if info.Sensitive && looksEncrypted(info.Value) {
+			continue
+		}
+
+		plaintext := info.Value
+		encrypted, err := EncryptSensitive(plaintext)
+		if err != nil {
+			CBLog.Error(err)
+			continue
+		}
+
+		info.Sensitive = true
+		info.Value = encrypted
+		updated, err := json.Marshal(info)
+		if err != nil {
+			CBLog.Error(err)
+			continue
+		}
+		if err := CBStore.Put(v.Key, string(updated)); err != nil {
+			CBLog.Error(err)
+			continue
+		}
+		fmt.Println("MigrateSensitiveConfig(); encrypted plaintext config: " + info.Id)
+	}
+
+	return nil
+}
+
+// looksEncrypted reports whether value round-trips through DecryptSensitive, used by
+// MigrateSensitiveConfig to avoid re-encrypting an already-encrypted value.
+func looksEncrypted(value string) bool {
+	_, err := DecryptSensitive(value)
+	return err == nil
+}
+
 func ListConfigId() []string {
 
 	fmt.Println("[List config]")
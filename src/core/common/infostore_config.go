@@ -0,0 +1,12 @@
+package common
+
+// StrINFOSTORE_DRIVER is the config key selecting the infostore (GORM) backend driver.
+const StrINFOSTORE_DRIVER = "INFOSTORE_DRIVER"
+
+// INFOSTORE_DRIVER selects the infostore backend: "sqlite" (default) or "mysql". When mysql is
+// selected, the existing DB_URL/DB_DATABASE/DB_USER/DB_PASSWORD config values are reused so
+// operators don't need to configure the connection twice.
+var INFOSTORE_DRIVER string
+
+// INFOSTORE_SQLITE_PATH is the file path used for the embedded sqlite infostore backend.
+var INFOSTORE_SQLITE_PATH string
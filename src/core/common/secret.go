@@ -0,0 +1,127 @@
+package common
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+)
+
+// StrSECRETS_BACKEND is the config key selecting which secretsProvider encrypts/decrypts
+// ConfigInfo values marked Sensitive.
+const StrSECRETS_BACKEND = "SECRETS_BACKEND"
+
+// SECRETS_BACKEND selects the secretsProvider. Only "env" (the default, AES-GCM keyed from
+// TB_CONFIG_MASTER_KEY) is supported; any other value, such as "vault" or "awssecretsmanager",
+// makes EncryptSensitive/DecryptSensitive return an error.
+var SECRETS_BACKEND string
+
+// RedactedValue is returned in place of a Sensitive ConfigInfo's real value by UpdateConfig,
+// GetConfig and ListConfig; only the internal getConfigReveal(id, true) path returns plaintext.
+const RedactedValue = "***"
+
+// secretsProvider encrypts/decrypts ConfigInfo values marked Sensitive before they touch the KV
+// store or a log line. Pluggable so a future Vault/AWS Secrets Manager backend can be swapped in
+// via SECRETS_BACKEND without touching callers.
+type secretsProvider interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+// getSecretsProvider resolves the configured secretsProvider. Defaults to "env" so existing
+// deployments keep working without extra setup.
+func getSecretsProvider() (secretsProvider, error) {
+	switch NVL(SECRETS_BACKEND, "env") {
+	case "env":
+		return &envAESProvider{}, nil
+	default:
+		return nil, fmt.Errorf("common: unsupported SECRETS_BACKEND %q (only \"env\" is wired in this build)", SECRETS_BACKEND)
+	}
+}
+
+// envAESProvider encrypts with AES-GCM using a key derived (via SHA-256) from the
+// TB_CONFIG_MASTER_KEY environment variable. The nonce is prepended to the ciphertext and the
+// whole thing is base64-encoded for storage as a normal ConfigInfo.Value string.
+type envAESProvider struct{}
+
+func (p *envAESProvider) key() ([]byte, error) {
+	master := os.Getenv("TB_CONFIG_MASTER_KEY")
+	if master == "" {
+		return nil, fmt.Errorf("common: TB_CONFIG_MASTER_KEY is not set; cannot encrypt/decrypt sensitive config")
+	}
+	sum := sha256.Sum256([]byte(master))
+	return sum[:], nil
+}
+
+func (p *envAESProvider) Encrypt(plaintext string) (string, error) {
+	key, err := p.key()
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+func (p *envAESProvider) Decrypt(ciphertext string) (string, error) {
+	key, err := p.key()
+	if err != nil {
+		return "", err
+	}
+	raw, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonceSize := gcm.NonceSize()
+	if len(raw) < nonceSize {
+		return "", fmt.Errorf("common: ciphertext too short")
+	}
+	nonce, encrypted := raw[:nonceSize], raw[nonceSize:]
+	plaintext, err := gcm.Open(nil, nonce, encrypted, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// EncryptSensitive encrypts plaintext using the configured secretsProvider, for storage in
+// ConfigInfo.Value when ConfigInfo.Sensitive is true.
+func EncryptSensitive(plaintext string) (string, error) {
+	provider, err := getSecretsProvider()
+	if err != nil {
+		return "", err
+	}
+	return provider.Encrypt(plaintext)
+}
+
+// DecryptSensitive decrypts a value previously produced by EncryptSensitive.
+func DecryptSensitive(ciphertext string) (string, error) {
+	provider, err := getSecretsProvider()
+	if err != nil {
+		return "", err
+	}
+	return provider.Decrypt(ciphertext)
+}
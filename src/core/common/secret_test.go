@@ -0,0 +1,48 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import "testing"
+
+func TestEnvAESProviderEncryptDecryptRoundTrip(t *testing.T) {
+	t.Setenv("TB_CONFIG_MASTER_KEY", "test-master-key")
+
+	provider := &envAESProvider{}
+	const plaintext = "s3cr3t-db-password"
+
+	ciphertext, err := provider.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if ciphertext == plaintext {
+		t.Fatalf("Encrypt() returned the plaintext unchanged")
+	}
+
+	got, err := provider.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if got != plaintext {
+		t.Errorf("Decrypt(Encrypt(plaintext)) = %q, want %q", got, plaintext)
+	}
+}
+
+func TestEnvAESProviderEncryptMissingMasterKey(t *testing.T) {
+	t.Setenv("TB_CONFIG_MASTER_KEY", "")
+
+	provider := &envAESProvider{}
+	if _, err := provider.Encrypt("anything"); err == nil {
+		t.Fatal("Encrypt() with no TB_CONFIG_MASTER_KEY set, want error, got nil")
+	}
+}
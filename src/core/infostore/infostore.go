@@ -0,0 +1,84 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package infostore is to manage a relational metadata store (via GORM) that
+// sits alongside the KV store. The KV store remains the source of truth for
+// coordination/locking and for the full JSON object; infostore holds a
+// normalized, queryable projection so callers can filter/join across
+// namespaces without walking every KV key.
+package infostore
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/rs/zerolog/log"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	db     *gorm.DB
+	dbOnce sync.Once
+	dbErr  error
+)
+
+// GetDefault returns the process-wide infostore handle, opening and migrating it on first use
+// according to the INFOSTORE_DRIVER config (sqlite|mysql, default sqlite).
+func GetDefault() (*gorm.DB, error) {
+	dbOnce.Do(func() {
+		db, dbErr = open()
+		if dbErr != nil {
+			log.Error().Err(dbErr).Msg("infostore: failed to open")
+			return
+		}
+		dbErr = db.AutoMigrate(&VNetRecord{}, &SubnetRecord{}, &VNetPeeringRecord{})
+		if dbErr != nil {
+			log.Error().Err(dbErr).Msg("infostore: failed to migrate")
+		}
+	})
+	return db, dbErr
+}
+
+// open dials the configured relational backend. Defaults to an embedded sqlite file so infostore
+// works out of the box; set INFOSTORE_DRIVER=mysql (plus DB_URL/DB_DATABASE/DB_USER/DB_PASSWORD,
+// which are already wired through common.UpdateEnv) to point at a shared MySQL instance.
+func open() (*gorm.DB, error) {
+	driver := common.NVL(common.INFOSTORE_DRIVER, "sqlite")
+
+	gormConfig := &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+
+	switch driver {
+	case "mysql":
+		dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			common.DB_USER, common.DB_PASSWORD, common.DB_URL, common.DB_DATABASE)
+		return gorm.Open(mysql.Open(dsn), gormConfig)
+	case "sqlite":
+		return gorm.Open(sqlite.Open(common.NVL(common.INFOSTORE_SQLITE_PATH, "infostore.db")), gormConfig)
+	default:
+		return nil, fmt.Errorf("infostore: unsupported INFOSTORE_DRIVER %q", driver)
+	}
+}
+
+// Migrate runs GORM auto-migration for the given models. Exported so other resource packages
+// (subnet, security group, etc.) can register their own tables as they adopt infostore.
+func Migrate(models ...interface{}) error {
+	d, err := GetDefault()
+	if err != nil {
+		return err
+	}
+	return d.AutoMigrate(models...)
+}
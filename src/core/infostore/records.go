@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infostore
+
+import "time"
+
+// VNetRecord is the infostore (GORM) projection of model.TbVNetInfo. It is written through
+// alongside the KV store so vNets can be queried/filtered across namespaces; the KV entry
+// remains the authoritative full object.
+type VNetRecord struct {
+	Id             string `gorm:"column:id;primaryKey"`
+	NsId           string `gorm:"column:ns_id;primaryKey;index"`
+	Name           string `gorm:"column:name;index"`
+	ConnectionName string `gorm:"column:connection_name;index"`
+	CidrBlock      string `gorm:"column:cidr_block;index"`
+	CspVNetId      string `gorm:"column:csp_vnet_id"`
+	CspVNetName    string `gorm:"column:csp_vnet_name"`
+	Status         string `gorm:"column:status"`
+	Description    string `gorm:"column:description"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// TableName follows the infostore naming convention: lower_snake_case, prefixed with "tb_".
+func (VNetRecord) TableName() string {
+	return "tb_vnet_info"
+}
+
+// SubnetRecord is the infostore projection of model.TbSubnetInfo, keyed by (NsId, VNetId, Id) so
+// ListSubnetByZone can filter across every vNet in a namespace without touching the KV store.
+type SubnetRecord struct {
+	Id          string `gorm:"column:id;primaryKey"`
+	NsId        string `gorm:"column:ns_id;primaryKey;index"`
+	VNetId      string `gorm:"column:vnet_id;primaryKey;index"`
+	Name        string `gorm:"column:name;index"`
+	Zone        string `gorm:"column:zone;index"`
+	IPv4CIDR    string `gorm:"column:ipv4_cidr"`
+	CspSubnetId string `gorm:"column:csp_subnet_id"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// TableName follows the infostore naming convention: lower_snake_case, prefixed with "tb_".
+func (SubnetRecord) TableName() string {
+	return "tb_subnet_info"
+}
+
+// VNetPeeringRecord is the infostore projection of model.TbVNetPeeringInfo, keyed by
+// (NsId, Id) so peerings can be listed/filtered the same way vNets and subnets are.
+type VNetPeeringRecord struct {
+	Id      string `gorm:"column:id;primaryKey"`
+	NsId    string `gorm:"column:ns_id;primaryKey;index"`
+	Name    string `gorm:"column:name;index"`
+	VNetIdA string `gorm:"column:vnet_id_a;index"`
+	VNetIdB string `gorm:"column:vnet_id_b;index"`
+	Mode    string `gorm:"column:mode;index"`
+	Status  string `gorm:"column:status;index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName follows the infostore naming convention: lower_snake_case, prefixed with "tb_".
+func (VNetPeeringRecord) TableName() string {
+	return "tb_vnet_peering"
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infostore
+
+import "gorm.io/gorm/clause"
+
+// UpsertVNet writes (or updates) the SQL projection of a vNet. Called by resource.CreateVNet
+// after the KV store write succeeds, during the KV->SQL migration period.
+//
+// This uses Create with an OnConflict clause rather than Save: the caller always populates the
+// primary key (Id/NsId) before calling, and GORM's Save executes a plain UPDATE (no insert
+// fallback) whenever the primary key is already set, which would silently no-op on the very
+// first write for a new record.
+func UpsertVNet(rec VNetRecord) error {
+	d, err := GetDefault()
+	if err != nil {
+		return err
+	}
+	return d.Clauses(clause.OnConflict{UpdateAll: true}).Create(&rec).Error
+}
+
+// DeleteVNet removes the SQL projection of a vNet and its subnets. Called by resource.DeleteVNet
+// alongside the KV store delete.
+func DeleteVNet(nsId string, vNetId string) error {
+	d, err := GetDefault()
+	if err != nil {
+		return err
+	}
+	if err := d.Where("ns_id = ? AND vnet_id = ?", nsId, vNetId).Delete(&SubnetRecord{}).Error; err != nil {
+		return err
+	}
+	return d.Where("ns_id = ? AND id = ?", nsId, vNetId).Delete(&VNetRecord{}).Error
+}
+
+// ListVNetByFilter queries vNets in nsId, optionally narrowed by connectionName and a CIDR
+// substring/prefix match (cidrLike may be "" to list all). This is the query KV can't do
+// efficiently: "find all vNets with a CIDR matching 10.0.%" across a namespace.
+func ListVNetByFilter(nsId string, connectionName string, cidrLike string) ([]VNetRecord, error) {
+	d, err := GetDefault()
+	if err != nil {
+		return nil, err
+	}
+	q := d.Where("ns_id = ?", nsId)
+	if connectionName != "" {
+		q = q.Where("connection_name = ?", connectionName)
+	}
+	if cidrLike != "" {
+		q = q.Where("cidr_block LIKE ?", cidrLike+"%")
+	}
+	var records []VNetRecord
+	err = q.Find(&records).Error
+	return records, err
+}
+
+// UpsertSubnet writes (or updates) the SQL projection of a subnet. See UpsertVNet for why this
+// uses Create+OnConflict instead of Save.
+func UpsertSubnet(rec SubnetRecord) error {
+	d, err := GetDefault()
+	if err != nil {
+		return err
+	}
+	return d.Clauses(clause.OnConflict{UpdateAll: true}).Create(&rec).Error
+}
+
+// ListSubnetByZone queries every subnet in nsId pinned to zone, across all vNets.
+func ListSubnetByZone(nsId string, zone string) ([]SubnetRecord, error) {
+	d, err := GetDefault()
+	if err != nil {
+		return nil, err
+	}
+	var records []SubnetRecord
+	err = d.Where("ns_id = ? AND zone = ?", nsId, zone).Find(&records).Error
+	return records, err
+}
+
+// UpsertVNetPeering writes (or updates) the SQL projection of a vNet peering. See UpsertVNet for
+// why this uses Create+OnConflict instead of Save.
+func UpsertVNetPeering(rec VNetPeeringRecord) error {
+	d, err := GetDefault()
+	if err != nil {
+		return err
+	}
+	return d.Clauses(clause.OnConflict{UpdateAll: true}).Create(&rec).Error
+}
+
+// DeleteVNetPeering removes the SQL projection of a vNet peering. Called by
+// resource.DeleteVNetPeering alongside the KV store delete.
+func DeleteVNetPeering(nsId string, peeringId string) error {
+	d, err := GetDefault()
+	if err != nil {
+		return err
+	}
+	return d.Where("ns_id = ? AND id = ?", nsId, peeringId).Delete(&VNetPeeringRecord{}).Error
+}
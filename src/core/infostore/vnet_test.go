@@ -0,0 +1,66 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infostore
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+)
+
+// TestUpsertVNetThenList guards against UpsertVNet regressing to a plain GORM Save: since the
+// caller always sets the primary key before calling, Save would execute an UPDATE that matches no
+// rows on a brand-new record and silently drop the insert.
+func TestUpsertVNetThenList(t *testing.T) {
+	common.INFOSTORE_SQLITE_PATH = filepath.Join(t.TempDir(), "infostore_test.db")
+
+	rec := VNetRecord{
+		Id:             "vnet-01",
+		NsId:           "ns-test",
+		Name:           "vnet-01",
+		ConnectionName: "aws-ap-northeast-2",
+		CidrBlock:      "10.0.0.0/16",
+	}
+	if err := UpsertVNet(rec); err != nil {
+		t.Fatalf("UpsertVNet() error = %v", err)
+	}
+
+	records, err := ListVNetByFilter("ns-test", "", "")
+	if err != nil {
+		t.Fatalf("ListVNetByFilter() error = %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("ListVNetByFilter() returned %d records, want 1", len(records))
+	}
+	if records[0].Id != rec.Id || records[0].CidrBlock != rec.CidrBlock {
+		t.Errorf("ListVNetByFilter() = %+v, want Id/CidrBlock matching %+v", records[0], rec)
+	}
+
+	// Re-upserting the same primary key should update in place, not insert a second row.
+	rec.CidrBlock = "10.1.0.0/16"
+	if err := UpsertVNet(rec); err != nil {
+		t.Fatalf("UpsertVNet() (update) error = %v", err)
+	}
+	records, err = ListVNetByFilter("ns-test", "", "")
+	if err != nil {
+		t.Fatalf("ListVNetByFilter() error = %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("ListVNetByFilter() after update returned %d records, want 1", len(records))
+	}
+	if records[0].CidrBlock != "10.1.0.0/16" {
+		t.Errorf("ListVNetByFilter() after update CidrBlock = %q, want %q", records[0].CidrBlock, "10.1.0.0/16")
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "github.com/cloud-barista/cb-tumblebug/src/core/common"
+
+// StrVNetPeering is the resource type string used to namespace TbVNetPeering keys in the KV store
+// (see common.GenResourceKey), alongside StrVNet and StrSubnet.
+const StrVNetPeering = "vNetPeering"
+
+// VNetPeeringMode is the peering topology between two vNets.
+type VNetPeeringMode string
+
+const (
+	PeeringModeSameCsp         VNetPeeringMode = "same-csp"
+	PeeringModeCrossCspVpn     VNetPeeringMode = "cross-csp-vpn"
+	PeeringModeCrossCspTransit VNetPeeringMode = "cross-csp-transit"
+)
+
+// VNetPeeringStatus tracks the lifecycle of a TbVNetPeering.
+type VNetPeeringStatus string
+
+const (
+	PeeringStatusPending     VNetPeeringStatus = "pending"
+	PeeringStatusEstablished VNetPeeringStatus = "established"
+	PeeringStatusFailed      VNetPeeringStatus = "failed"
+)
+
+// TbVNetPeeringReq is a struct to handle 'Create vNet peering' request toward CB-Tumblebug.
+type TbVNetPeeringReq struct {
+	Name        string          `json:"name" validate:"required"`
+	VNetIdA     string          `json:"vNetIdA" validate:"required"`
+	VNetIdB     string          `json:"vNetIdB" validate:"required"`
+	Mode        VNetPeeringMode `json:"mode" validate:"required"`
+	Description string          `json:"description"`
+}
+
+// TbVNetPeeringInfo is a struct that represents a TB vNet peering object.
+type TbVNetPeeringInfo struct {
+	Id          string            `json:"id"`
+	Name        string            `json:"name"`
+	NsId        string            `json:"nsId"`
+	VNetIdA     string            `json:"vNetIdA"`
+	VNetIdB     string            `json:"vNetIdB"`
+	Mode        VNetPeeringMode   `json:"mode"`
+	Status      VNetPeeringStatus `json:"status"`
+	Description string            `json:"description"`
+	// HealthMessage holds the error from a failed establish attempt and is empty otherwise; no
+	// health probe updates it after CreateVNetPeering returns.
+	HealthMessage string            `json:"healthMessage,omitempty"`
+	KeyValueList  []common.KeyValue `json:"keyValueList,omitempty"`
+}
@@ -0,0 +1,316 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
+	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// backupSchemaVersion is bumped whenever the on-disk layout under a backup directory changes in
+// a way RestoreNamespace needs to know about.
+const backupSchemaVersion = "1"
+
+// backupResourceOrder is the dependency order RestoreNamespace replays creations in: a vNet must
+// exist before its subnets, which in turn must exist before any VM referencing them.
+var backupResourceOrder = []string{
+	model.StrVNet,
+	model.StrSubnet,
+	model.StrImage,
+	model.StrSpec,
+	model.StrSecurityGroup,
+	model.StrSSHKey,
+	model.StrVM,
+}
+
+// BackupManifest describes a namespace backup: the schema version it was written with, when it
+// was taken, and the Tumblebug/Spider versions that produced it, so RestoreNamespace can refuse
+// to replay an incompatible or unrecognized snapshot.
+type BackupManifest struct {
+	SchemaVersion    string   `json:"schemaVersion"`
+	NsId             string   `json:"nsId"`
+	Timestamp        string   `json:"timestamp"`
+	TumblebugVersion string   `json:"tumblebugVersion"`
+	SpiderVersion    string   `json:"spiderVersion"`
+	ResourceTypes    []string `json:"resourceTypes"`
+}
+
+// backupKeyValues fetches the raw KV entries for resourceType. Subnets are child resources keyed
+// under their owning vNet (see common.GenChildResourceKey usage in ListSubnetByZone) rather than a
+// flat top-level type, so they're enumerated per already-seen vNetId instead of via a single
+// GenResourceKey prefix.
+func backupKeyValues(nsId string, resourceType string, vNetIds []string) ([]common.KeyValue, error) {
+	if resourceType != model.StrSubnet {
+		key := common.GenResourceKey(nsId, resourceType, "")
+		return kvstore.GetList(key, true)
+	}
+
+	var keyValues []common.KeyValue
+	for _, vNetId := range vNetIds {
+		childKey := common.GenChildResourceKey(nsId, model.StrSubnet, vNetId, "")
+		childKeyValues, err := kvstore.GetList(childKey, true)
+		if err != nil {
+			return nil, err
+		}
+		keyValues = append(keyValues, childKeyValues...)
+	}
+	return keyValues, nil
+}
+
+// BackupNamespace serializes every resource (vNet, subnet, image, spec, security group, key, ...)
+// of nsId as one JSON file per item under dirPath/<resourceType>/<id>.json, plus a manifest.json.
+func BackupNamespace(nsId string, dirPath string) (BackupManifest, error) {
+	manifest := BackupManifest{
+		SchemaVersion:    backupSchemaVersion,
+		NsId:             nsId,
+		Timestamp:        common.NowString(),
+		TumblebugVersion: model.TumblebugVersion,
+		SpiderVersion:    model.SpiderVersion,
+	}
+
+	err := common.CheckString(nsId)
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		return manifest, err
+	}
+
+	var vNetIds []string
+
+	for _, resourceType := range backupResourceOrder {
+		keyValues, err := backupKeyValues(nsId, resourceType, vNetIds)
+		if err != nil {
+			log.Error().Err(err).Msg("")
+			return manifest, err
+		}
+		if len(keyValues) == 0 {
+			continue
+		}
+
+		typeDir := filepath.Join(dirPath, resourceType)
+		if err := os.MkdirAll(typeDir, 0755); err != nil {
+			log.Error().Err(err).Msg("")
+			return manifest, err
+		}
+
+		for _, kv := range keyValues {
+			id := filepath.Base(kv.Key)
+			itemPath := filepath.Join(typeDir, id+".json")
+			if err := os.WriteFile(itemPath, []byte(kv.Value), 0644); err != nil {
+				log.Error().Err(err).Msg("")
+				return manifest, err
+			}
+			if resourceType == model.StrVNet {
+				vNetIds = append(vNetIds, id)
+			}
+		}
+
+		manifest.ResourceTypes = append(manifest.ResourceTypes, resourceType)
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		return manifest, err
+	}
+	if err := os.WriteFile(filepath.Join(dirPath, "manifest.json"), manifestBytes, 0644); err != nil {
+		log.Error().Err(err).Msg("")
+		return manifest, err
+	}
+
+	return manifest, nil
+}
+
+// RestoreResult reports what RestoreNamespace did (or, for a dry run, would do) for a single item.
+type RestoreResult struct {
+	ResourceType string `json:"resourceType"`
+	Id           string `json:"id"`
+	Action       string `json:"action"` // "created", "skipped_exists", "would_create"
+	Error        string `json:"error,omitempty"`
+}
+
+// RestoreNamespace validates dirPath/manifest.json and replays every backed-up resource into
+// nsId in dependency order (vNet before subnet before VM). When a vNet's CspVNetId is still
+// present in the backup and the CSP resource still exists, it is registered via the existing
+// option=="register" path in CreateVNet rather than recreated, preserving the original CspVNetId.
+// With dryRun, no writes happen; RestoreNamespace only reports what it would do.
+func RestoreNamespace(nsId string, dirPath string, dryRun bool) ([]RestoreResult, error) {
+	var results []RestoreResult
+
+	manifestBytes, err := os.ReadFile(filepath.Join(dirPath, "manifest.json"))
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		return results, err
+	}
+
+	manifest := BackupManifest{}
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		log.Error().Err(err).Msg("")
+		return results, err
+	}
+	if manifest.SchemaVersion != backupSchemaVersion {
+		err := fmt.Errorf("backup schema version %s is not supported (expected %s)", manifest.SchemaVersion, backupSchemaVersion)
+		log.Error().Err(err).Msg("")
+		return results, err
+	}
+
+	for _, resourceType := range backupResourceOrder {
+		typeDir := filepath.Join(dirPath, resourceType)
+		entries, err := os.ReadDir(typeDir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			log.Error().Err(err).Msg("")
+			return results, err
+		}
+
+		for _, entry := range entries {
+			id := trimJsonExt(entry.Name())
+			result := restoreOneItem(nsId, resourceType, filepath.Join(typeDir, entry.Name()), id, dryRun)
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
+}
+
+// restoreOneItem restores (or, under dryRun, previews restoring) a single backed-up resource.
+func restoreOneItem(nsId string, resourceType string, itemPath string, id string, dryRun bool) RestoreResult {
+	result := RestoreResult{ResourceType: resourceType, Id: id}
+
+	check, err := CheckResource(nsId, resourceType, id)
+	if err != nil {
+		result.Action = "skipped_exists"
+		result.Error = err.Error()
+		return result
+	}
+	if check {
+		result.Action = "skipped_exists"
+		return result
+	}
+
+	if dryRun {
+		result.Action = "would_create"
+		return result
+	}
+
+	itemBytes, err := os.ReadFile(itemPath)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	switch resourceType {
+	case model.StrVNet:
+		vNetInfo := model.TbVNetInfo{}
+		if err := json.Unmarshal(itemBytes, &vNetInfo); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		req := vNetReqFromInfo(vNetInfo)
+		option := ""
+		// A backed-up CspVNetId is only trustworthy as a register target if the CSP resource is
+		// still actually there; in the primary DR scenario this feature exists for (restoring into
+		// a fresh environment), it won't be, and CreateVNet(option="register") would just fail.
+		if vNetInfo.CspVNetId != "" && cspVNetExists(vNetInfo) {
+			option = "register"
+		}
+		if _, err := CreateVNet(nsId, &req, option); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	case model.StrSubnet:
+		// Subnets are child resources keyed under their owning vNet (see backupKeyValues), so the
+		// generic flat-key fallback below would write them somewhere ListSubnetByZone/GetSubnet
+		// would never look.
+		subnetInfo := model.TbSubnetInfo{}
+		if err := json.Unmarshal(itemBytes, &subnetInfo); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		key := common.GenChildResourceKey(nsId, model.StrSubnet, subnetInfo.VNetId, id)
+		if err := kvstore.Put(key, string(itemBytes)); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	default:
+		// image/spec/securityGroup/sshKey/vm don't have a CreateX(option="register") path in this
+		// tree yet, so there's no way to re-provision (or re-verify) their live CSP counterpart.
+		// Replay the TB metadata record itself, so the namespace's resource catalog comes back even
+		// though any live CSP resource behind it (e.g. a security group or SSH key) is not
+		// re-provisioned and must be reconciled separately (see ExamineVNet for the vNet/subnet
+		// equivalent).
+		key := common.GenResourceKey(nsId, resourceType, id)
+		if err := kvstore.Put(key, string(itemBytes)); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	}
+
+	result.Action = "created"
+	return result
+}
+
+// cspVNetExists probes CB-Spider for vNetInfo's CspVNetName, so restoreOneItem only trusts a
+// backed-up CspVNetId as a register target when the CSP resource is confirmed still there.
+func cspVNetExists(vNetInfo model.TbVNetInfo) bool {
+	client := resty.New()
+	requestBody := model.SpiderVPCReqInfoWrapper{ConnectionName: vNetInfo.ConnectionName}
+	var cspVNet model.SpiderVPCInfo
+	url := fmt.Sprintf("%s/vpc/%s", model.SpiderRestUrl, vNetInfo.CspVNetName)
+	resp, err := client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(requestBody).
+		SetResult(&cspVNet).
+		Get(url)
+	return err == nil && resp.StatusCode() == 200
+}
+
+// vNetReqFromInfo reconstructs a TbVNetReq from a backed-up TbVNetInfo so it can be replayed
+// through CreateVNet(option="register"), preserving CspVNetId when the CSP resource still exists.
+func vNetReqFromInfo(info model.TbVNetInfo) model.TbVNetReq {
+	req := model.TbVNetReq{
+		Name:           info.Name,
+		ConnectionName: info.ConnectionName,
+		CidrBlock:      info.CidrBlock,
+		Description:    info.Description,
+		CspVNetId:      info.CspVNetId,
+	}
+	for _, s := range info.SubnetInfoList {
+		req.SubnetInfoList = append(req.SubnetInfoList, model.TbSubnetReq{
+			Name:      s.Name,
+			IdFromCsp: s.CspSubnetId,
+			IPv4_CIDR: s.IPv4_CIDR,
+			Zone:      s.Zone,
+		})
+	}
+	return req
+}
+
+// trimJsonExt strips a trailing ".json" from name, returning name unchanged if absent.
+func trimJsonExt(name string) string {
+	const ext = ".json"
+	if len(name) > len(ext) && name[len(name)-len(ext):] == ext {
+		return name[:len(name)-len(ext)]
+	}
+	return name
+}
@@ -0,0 +1,36 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import "testing"
+
+func TestTrimJsonExt(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"strips .json suffix", "vnet-1.json", "vnet-1"},
+		{"leaves name without suffix unchanged", "vnet-1", "vnet-1"},
+		{"leaves bare suffix unchanged", ".json", ".json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trimJsonExt(tt.in); got != tt.want {
+				t.Errorf("trimJsonExt(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/infostore"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
+	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// ExamineIssueType enumerates the kinds of drift ExamineVNet can detect between a TB record and
+// the live CB-Spider/CSP state.
+type ExamineIssueType string
+
+const (
+	IssueMissingInCsp           ExamineIssueType = "missing_in_csp"
+	IssueMissingInTb            ExamineIssueType = "missing_in_tb"
+	IssueCidrDrift              ExamineIssueType = "cidr_drift"
+	IssueOrphanAssociatedObject ExamineIssueType = "orphan_associated_object"
+	IssueConstraintIdMissing    ExamineIssueType = "constraint_id_missing"
+)
+
+// ExamineIssue is a single consistency issue found by ExamineVNet, serializable for CI.
+type ExamineIssue struct {
+	ResourceType string           `json:"resourceType"`
+	ResourceId   string           `json:"resourceId"`
+	Type         ExamineIssueType `json:"type"`
+	Message      string           `json:"message"`
+	Repaired     bool             `json:"repaired"`
+}
+
+// ExamineVNetResult is the JSON report returned by ExamineVNet.
+type ExamineVNetResult struct {
+	NsId     string         `json:"nsId"`
+	Examined int            `json:"examined"`
+	Issues   []ExamineIssue `json:"issues"`
+}
+
+// ExamineVNet walks every TbVNetInfo stored in the KV store for nsId and compares it against the
+// live CB-Spider VPC: CspVNetId, IPv4_CIDR, and each SubnetInfoList entry. When repair is true it
+// purges TB records whose CSP counterpart is gone and drops AssociatedObjectList entries that no
+// longer resolve to a live resource; re-registering a vNet that exists in CSP but not in TB is left
+// to the caller (CreateVNet with option=="register"), since the target namespace is ambiguous.
+func ExamineVNet(nsId string, repair bool) (ExamineVNetResult, error) {
+	result := ExamineVNetResult{NsId: nsId}
+
+	err := common.CheckString(nsId)
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		return result, err
+	}
+
+	key := common.GenResourceKey(nsId, model.StrVNet, "")
+	keyValues, err := kvstore.GetList(key, true)
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		return result, err
+	}
+
+	client := resty.New()
+	for _, kv := range keyValues {
+		tbVNet := model.TbVNetInfo{}
+		if err := json.Unmarshal([]byte(kv.Value), &tbVNet); err != nil {
+			log.Error().Err(err).Msg("")
+			continue
+		}
+		result.Examined++
+
+		issues, changed := examineOneVNet(client, nsId, &tbVNet, repair)
+		result.Issues = append(result.Issues, issues...)
+
+		if repair && changed {
+			val, err := json.Marshal(tbVNet)
+			if err != nil {
+				log.Error().Err(err).Msg("")
+				continue
+			}
+			if err := kvstore.Put(kv.Key, string(val)); err != nil {
+				log.Error().Err(err).Msg("")
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// examineOneVNet compares a single TbVNetInfo against CB-Spider and returns the issues found.
+// When repair is true, tbVNet is mutated in place (e.g. AssociatedObjectList pruned) so the caller
+// can persist the pruned record back to the KV store; changed reports whether it did so.
+func examineOneVNet(client *resty.Client, nsId string, tbVNet *model.TbVNetInfo, repair bool) ([]ExamineIssue, bool) {
+	var issues []ExamineIssue
+	changed := false
+
+	requestBody := model.SpiderVPCReqInfoWrapper{ConnectionName: tbVNet.ConnectionName}
+	var cspVNet model.SpiderVPCInfo
+	url := fmt.Sprintf("%s/vpc/%s", model.SpiderRestUrl, tbVNet.CspVNetName)
+	// A doctor --repair run is exactly the tool an operator reaches for against a live, possibly
+	// flaky system, so it must not treat a transient network/auth failure the same as a confirmed
+	// 404. Call resty directly (instead of common.ExecuteHttpRequest, which only surfaces a plain
+	// error) so the HTTP status code is available to tell the two apart.
+	resp, err := client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(requestBody).
+		SetResult(&cspVNet).
+		Get(url)
+	if err != nil || resp.StatusCode() != 200 {
+		issue := ExamineIssue{
+			ResourceType: model.StrVNet,
+			ResourceId:   tbVNet.Id,
+			Type:         IssueMissingInCsp,
+			Message:      fmt.Sprintf("vNet %s not found in CB-Spider: %v", tbVNet.CspVNetName, examineErrOrStatus(err, resp)),
+		}
+		if repair && err == nil && resp.StatusCode() == 404 {
+			// Only a confirmed 404 means the CSP resource is actually gone; routing that case
+			// through DeleteVNet would just issue a CB-Spider DELETE against the same missing
+			// object and fail before it ever reaches the KV/infostore cleanup, so purge the stale
+			// TB record directly instead. Any other failure (timeout, 5xx, auth) is left alone so
+			// a flaky CB-Spider call can never delete a valid TB record.
+			if err := purgeVNetRecord(nsId, tbVNet.Id); err != nil {
+				log.Error().Err(err).Msg("")
+			} else {
+				issue.Repaired = true
+			}
+		}
+		return append(issues, issue), changed
+	}
+
+	if cspVNet.IId.SystemId != tbVNet.CspVNetId {
+		issues = append(issues, ExamineIssue{
+			ResourceType: model.StrVNet,
+			ResourceId:   tbVNet.Id,
+			Type:         IssueConstraintIdMissing,
+			Message:      fmt.Sprintf("CspVNetId mismatch: tb=%s csp=%s", tbVNet.CspVNetId, cspVNet.IId.SystemId),
+		})
+	}
+
+	if cspVNet.IPv4_CIDR != tbVNet.CidrBlock {
+		issues = append(issues, ExamineIssue{
+			ResourceType: model.StrVNet,
+			ResourceId:   tbVNet.Id,
+			Type:         IssueCidrDrift,
+			Message:      fmt.Sprintf("CIDR drift: tb=%s csp=%s", tbVNet.CidrBlock, cspVNet.IPv4_CIDR),
+		})
+	}
+
+	issues = append(issues, examineSubnets(*tbVNet, cspVNet)...)
+
+	for _, assocKey := range tbVNet.AssociatedObjectList {
+		keyValue, err := kvstore.GetKv(assocKey)
+		if err != nil {
+			log.Error().Err(err).Msg("")
+			continue
+		}
+		if keyValue == nil {
+			issue := ExamineIssue{
+				ResourceType: model.StrVNet,
+				ResourceId:   tbVNet.Id,
+				Type:         IssueOrphanAssociatedObject,
+				Message:      fmt.Sprintf("associated object %s no longer exists", assocKey),
+			}
+			if repair {
+				tbVNet.AssociatedObjectList = removeString(tbVNet.AssociatedObjectList, assocKey)
+				issue.Repaired = true
+				changed = true
+			}
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues, changed
+}
+
+// examineSubnets diffs tbVNet.SubnetInfoList against the CB-Spider VPC's SubnetInfoList, reporting
+// subnets present on one side only and CIDR drift for subnets present on both.
+func examineSubnets(tbVNet model.TbVNetInfo, cspVNet model.SpiderVPCInfo) []ExamineIssue {
+	var issues []ExamineIssue
+
+	cspByName := map[string]model.SpiderSubnetInfo{}
+	for _, s := range cspVNet.SubnetInfoList {
+		cspByName[s.IId.NameId] = s
+	}
+
+	seen := map[string]bool{}
+	for _, tbSubnet := range tbVNet.SubnetInfoList {
+		seen[tbSubnet.CspSubnetName] = true
+		cspSubnet, ok := cspByName[tbSubnet.CspSubnetName]
+		if !ok {
+			issues = append(issues, ExamineIssue{
+				ResourceType: model.StrSubnet,
+				ResourceId:   tbSubnet.Id,
+				Type:         IssueMissingInCsp,
+				Message:      fmt.Sprintf("subnet %s not found in CB-Spider vNet %s", tbSubnet.CspSubnetName, tbVNet.CspVNetName),
+			})
+			continue
+		}
+		if cspSubnet.IPv4_CIDR != tbSubnet.IPv4_CIDR {
+			issues = append(issues, ExamineIssue{
+				ResourceType: model.StrSubnet,
+				ResourceId:   tbSubnet.Id,
+				Type:         IssueCidrDrift,
+				Message:      fmt.Sprintf("CIDR drift: tb=%s csp=%s", tbSubnet.IPv4_CIDR, cspSubnet.IPv4_CIDR),
+			})
+		}
+	}
+
+	for name := range cspByName {
+		if !seen[name] {
+			issues = append(issues, ExamineIssue{
+				ResourceType: model.StrSubnet,
+				ResourceId:   name,
+				Type:         IssueMissingInTb,
+				Message:      fmt.Sprintf("subnet %s exists in CB-Spider but not in TB vNet %s", name, tbVNet.Id),
+			})
+		}
+	}
+
+	return issues
+}
+
+// purgeVNetRecord removes the TB record for vNetId from the KV store and its infostore SQL
+// projection without touching CB-Spider, for use once the CSP counterpart is already confirmed
+// gone (unlike DeleteVNet, which always deletes the CSP resource first and would otherwise fail
+// against an object that no longer exists).
+func purgeVNetRecord(nsId string, vNetId string) error {
+	key := common.GenResourceKey(nsId, model.StrVNet, vNetId)
+	if err := kvstore.Delete(key); err != nil {
+		log.Error().Err(err).Msg("")
+		return err
+	}
+
+	if err := infostore.DeleteVNet(nsId, vNetId); err != nil {
+		log.Error().Err(err).Msg("infostore: failed to delete vNet record")
+	}
+
+	return nil
+}
+
+// examineErrOrStatus renders whichever of err/resp actually carries the failure, for the
+// IssueMissingInCsp message: a transport-level err (no resp) or a non-2xx HTTP status.
+func examineErrOrStatus(err error, resp *resty.Response) string {
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("HTTP %s", resp.Status())
+}
+
+// removeString returns a copy of list with every occurrence of s removed.
+func removeString(list []string, s string) []string {
+	out := make([]string, 0, len(list))
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRemoveString(t *testing.T) {
+	tests := []struct {
+		name string
+		list []string
+		s    string
+		want []string
+	}{
+		{"removes all occurrences", []string{"a", "b", "a", "c"}, "a", []string{"b", "c"}},
+		{"absent value leaves list unchanged", []string{"a", "b"}, "z", []string{"a", "b"}},
+		{"empty list", []string{}, "a", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := removeString(tt.list, tt.s)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("removeString(%v, %q) = %v, want %v", tt.list, tt.s, got, tt.want)
+			}
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/cloud-barista/cb-tumblebug/src/core/common"
 	"github.com/cloud-barista/cb-tumblebug/src/core/common/label"
+	"github.com/cloud-barista/cb-tumblebug/src/core/infostore"
 	"github.com/cloud-barista/cb-tumblebug/src/core/model"
 	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
 	validator "github.com/go-playground/validator/v10"
@@ -261,6 +262,24 @@ func CreateVNet(nsId string, u *model.TbVNetReq, option string) (model.TbVNetInf
 		return content, err
 	}
 
+	// Write through to the infostore SQL backend so vNets can be filtered/listed across
+	// namespaces. The KV store above remains authoritative; infostore failures are logged but
+	// do not fail the request during this migration period.
+	err = infostore.UpsertVNet(infostore.VNetRecord{
+		Id:             content.Id,
+		NsId:           nsId,
+		Name:           content.Name,
+		ConnectionName: content.ConnectionName,
+		CidrBlock:      content.CidrBlock,
+		CspVNetId:      content.CspVNetId,
+		CspVNetName:    content.CspVNetName,
+		Status:         content.Status,
+		Description:    content.Description,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("infostore: failed to write through vNet record")
+	}
+
 	for _, v := range callResult.SubnetInfoList {
 		jsonBody, err := json.Marshal(v)
 		if err != nil {
@@ -275,9 +294,23 @@ func CreateVNet(nsId string, u *model.TbVNetReq, option string) (model.TbVNetInf
 		tbSubnetReq.Name = v.IId.NameId
 		tbSubnetReq.IdFromCsp = v.IId.SystemId
 
-		_, err = CreateSubnet(nsId, content.Id, tbSubnetReq, true)
+		tbSubnetInfo, err := CreateSubnet(nsId, content.Id, tbSubnetReq, true)
 		if err != nil {
 			log.Error().Err(err).Msg("")
+			continue
+		}
+
+		err = infostore.UpsertSubnet(infostore.SubnetRecord{
+			Id:          tbSubnetInfo.Id,
+			NsId:        nsId,
+			VNetId:      content.Id,
+			Name:        tbSubnetInfo.Name,
+			Zone:        v.Zone,
+			IPv4CIDR:    tbSubnetInfo.IPv4_CIDR,
+			CspSubnetId: v.IId.SystemId,
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("infostore: failed to write through subnet record")
 		}
 	}
 
@@ -308,3 +341,123 @@ func CreateVNet(nsId string, u *model.TbVNetReq, option string) (model.TbVNetInf
 
 	return result, nil
 }
+
+// DeleteVNet deletes a TB vNet object, removing the CSP resource via CB-Spider and then both the
+// KV store record and its infostore SQL projection.
+func DeleteVNet(nsId string, vNetId string) error {
+	log.Info().Msg("DeleteVNet")
+	resourceType := model.StrVNet
+
+	err := common.CheckString(nsId)
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		return err
+	}
+
+	if hasActivePeering(nsId, vNetId) {
+		return fmt.Errorf("The vNet " + vNetId + " cannot be deleted while a vNet peering references it.")
+	}
+
+	Key := common.GenResourceKey(nsId, resourceType, vNetId)
+	keyValue, err := kvstore.GetKv(Key)
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		return err
+	}
+	if keyValue == nil {
+		return fmt.Errorf("The vNet " + vNetId + " does not exist.")
+	}
+
+	vNetInfo := model.TbVNetInfo{}
+	err = json.Unmarshal([]byte(keyValue.Value), &vNetInfo)
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		return err
+	}
+
+	client := resty.New()
+	var callResult interface{}
+	url := fmt.Sprintf("%s/vpc/%s?connection_name=%s", model.SpiderRestUrl, vNetInfo.CspVNetName, vNetInfo.ConnectionName)
+	err = common.ExecuteHttpRequest(
+		client,
+		"DELETE",
+		url,
+		nil,
+		common.SetUseBody(callResult),
+		&callResult,
+		&callResult,
+		common.MediumDuration,
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		return err
+	}
+
+	err = kvstore.Delete(Key)
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		return err
+	}
+
+	err = infostore.DeleteVNet(nsId, vNetId)
+	if err != nil {
+		log.Error().Err(err).Msg("infostore: failed to delete vNet record")
+	}
+
+	return nil
+}
+
+// ListVNetByFilter lists vNets in nsId via the infostore SQL backend, optionally narrowed by
+// connectionName and a CIDR prefix (cidrPrefix may be "" to skip that filter). Unlike the KV
+// store, this supports a query across every vNet in the namespace without walking each key.
+func ListVNetByFilter(nsId string, connectionName string, cidrPrefix string) ([]model.TbVNetInfo, error) {
+	records, err := infostore.ListVNetByFilter(nsId, connectionName, cidrPrefix)
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		return nil, err
+	}
+
+	result := []model.TbVNetInfo{}
+	for _, r := range records {
+		Key := common.GenResourceKey(nsId, model.StrVNet, r.Id)
+		keyValue, err := kvstore.GetKv(Key)
+		if err != nil || keyValue == nil {
+			log.Error().Err(err).Msg("infostore record has no matching KV entry: " + r.Id)
+			continue
+		}
+		vNetInfo := model.TbVNetInfo{}
+		if err := json.Unmarshal([]byte(keyValue.Value), &vNetInfo); err != nil {
+			log.Error().Err(err).Msg("")
+			continue
+		}
+		result = append(result, vNetInfo)
+	}
+	return result, nil
+}
+
+// ListSubnetByZone lists every subnet pinned to zone across all vNets in nsId, via the infostore
+// SQL backend.
+func ListSubnetByZone(nsId string, zone string) ([]model.TbSubnetInfo, error) {
+	records, err := infostore.ListSubnetByZone(nsId, zone)
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		return nil, err
+	}
+
+	result := []model.TbSubnetInfo{}
+	for _, r := range records {
+		Key := common.GenChildResourceKey(nsId, model.StrSubnet, r.VNetId, r.Id)
+		keyValue, err := kvstore.GetKv(Key)
+		if err != nil || keyValue == nil {
+			log.Error().Err(err).Msg("infostore record has no matching KV entry: " + r.Id)
+			continue
+		}
+		subnetInfo := model.TbSubnetInfo{}
+		if err := json.Unmarshal([]byte(keyValue.Value), &subnetInfo); err != nil {
+			log.Error().Err(err).Msg("")
+			continue
+		}
+		result = append(result, subnetInfo)
+	}
+	return result, nil
+}
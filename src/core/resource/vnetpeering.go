@@ -0,0 +1,397 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/cloud-barista/cb-tumblebug/src/core/common"
+	"github.com/cloud-barista/cb-tumblebug/src/core/infostore"
+	"github.com/cloud-barista/cb-tumblebug/src/core/model"
+	"github.com/cloud-barista/cb-tumblebug/src/kvstore/kvstore"
+	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// CreateVNetPeering validates nsId/req, provisions the peering (same-CSP via CB-Spider's peering
+// endpoint, cross-CSP via a site-to-site IPsec tunnel built from Spider primitives), and records
+// it in the KV store. Unless the attempt failed, the peering key is also added to both vNets'
+// AssociatedObjectList so DeleteVNet can refuse to remove a vNet that is still peered.
+func CreateVNetPeering(nsId string, req *model.TbVNetPeeringReq) (model.TbVNetPeeringInfo, error) {
+	log.Info().Msg("CreateVNetPeering")
+	info := model.TbVNetPeeringInfo{}
+
+	err := common.CheckString(nsId)
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		return info, err
+	}
+
+	vNetA, vNetB, err := loadPeerVNets(nsId, req.VNetIdA, req.VNetIdB)
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		return info, err
+	}
+
+	if err := checkCidrNonOverlap(vNetA.CidrBlock, vNetB.CidrBlock); err != nil {
+		log.Error().Err(err).Msg("")
+		return info, err
+	}
+
+	info.Id = common.GenUid()
+	info.Name = req.Name
+	info.NsId = nsId
+	info.VNetIdA = req.VNetIdA
+	info.VNetIdB = req.VNetIdB
+	info.Mode = req.Mode
+	info.Description = req.Description
+	info.Status = model.PeeringStatusPending
+
+	switch req.Mode {
+	case model.PeeringModeSameCsp:
+		err = establishSameCspPeering(vNetA, vNetB, &info)
+	case model.PeeringModeCrossCspVpn, model.PeeringModeCrossCspTransit:
+		err = establishCrossCspPeering(vNetA, vNetB, &info)
+	default:
+		err = fmt.Errorf("unsupported vNet peering mode %q", req.Mode)
+	}
+
+	if err != nil {
+		info.Status = model.PeeringStatusFailed
+		info.HealthMessage = err.Error()
+		log.Error().Err(err).Msg("")
+	} else {
+		info.Status = model.PeeringStatusEstablished
+	}
+
+	Key := common.GenResourceKey(nsId, model.StrVNetPeering, info.Id)
+	Val, marshalErr := json.Marshal(info)
+	if marshalErr != nil {
+		log.Error().Err(marshalErr).Msg("")
+		return info, marshalErr
+	}
+	if putErr := kvstore.Put(Key, string(Val)); putErr != nil {
+		log.Error().Err(putErr).Msg("")
+		return info, putErr
+	}
+
+	// A failed peering never came up, so it must not block DeleteVNet on either side the way a
+	// pending/established one does; only record the association once the attempt stuck.
+	if info.Status != model.PeeringStatusFailed {
+		if linkErr := addPeeringAssociation(nsId, req.VNetIdA, Key); linkErr != nil {
+			log.Error().Err(linkErr).Msg("")
+		}
+		if linkErr := addPeeringAssociation(nsId, req.VNetIdB, Key); linkErr != nil {
+			log.Error().Err(linkErr).Msg("")
+		}
+	}
+
+	if storeErr := infostore.UpsertVNetPeering(infostore.VNetPeeringRecord{
+		Id:      info.Id,
+		NsId:    nsId,
+		Name:    info.Name,
+		VNetIdA: info.VNetIdA,
+		VNetIdB: info.VNetIdB,
+		Mode:    string(info.Mode),
+		Status:  string(info.Status),
+	}); storeErr != nil {
+		log.Error().Err(storeErr).Msg("infostore: failed to write through vNet peering record")
+	}
+
+	if err != nil {
+		return info, err
+	}
+	return info, nil
+}
+
+// loadPeerVNets fetches both sides of a peering request from the KV store.
+func loadPeerVNets(nsId string, vNetIdA string, vNetIdB string) (model.TbVNetInfo, model.TbVNetInfo, error) {
+	var vNetA, vNetB model.TbVNetInfo
+
+	for _, pair := range []struct {
+		id     string
+		target *model.TbVNetInfo
+	}{{vNetIdA, &vNetA}, {vNetIdB, &vNetB}} {
+		Key := common.GenResourceKey(nsId, model.StrVNet, pair.id)
+		keyValue, err := kvstore.GetKv(Key)
+		if err != nil {
+			return vNetA, vNetB, err
+		}
+		if keyValue == nil {
+			return vNetA, vNetB, fmt.Errorf("vNet %s does not exist in namespace %s", pair.id, nsId)
+		}
+		if err := json.Unmarshal([]byte(keyValue.Value), pair.target); err != nil {
+			return vNetA, vNetB, err
+		}
+	}
+
+	return vNetA, vNetB, nil
+}
+
+// checkCidrNonOverlap returns an error if cidrA and cidrB overlap; two vNets cannot be peered
+// (same-CSP or cross-CSP) if their address spaces collide.
+func checkCidrNonOverlap(cidrA string, cidrB string) error {
+	_, netA, err := net.ParseCIDR(cidrA)
+	if err != nil {
+		return fmt.Errorf("invalid CIDR %q: %w", cidrA, err)
+	}
+	_, netB, err := net.ParseCIDR(cidrB)
+	if err != nil {
+		return fmt.Errorf("invalid CIDR %q: %w", cidrB, err)
+	}
+
+	if netA.Contains(netB.IP) || netB.Contains(netA.IP) {
+		return fmt.Errorf("CIDR %s overlaps with %s", cidrA, cidrB)
+	}
+	return nil
+}
+
+// establishSameCspPeering calls CB-Spider's VPC peering endpoint for two vNets on the same CSP
+// connection.
+func establishSameCspPeering(vNetA model.TbVNetInfo, vNetB model.TbVNetInfo, info *model.TbVNetPeeringInfo) error {
+	client := resty.New()
+	requestBody := struct {
+		ConnectionName string
+		Name           string
+		VPCName        string
+		PeerVPCName    string
+	}{
+		ConnectionName: vNetA.ConnectionName,
+		Name:           info.Name,
+		VPCName:        vNetA.CspVNetName,
+		PeerVPCName:    vNetB.CspVNetName,
+	}
+
+	var callResult interface{}
+	url := fmt.Sprintf("%s/vpcpeering", model.SpiderRestUrl)
+	return common.ExecuteHttpRequest(
+		client,
+		"POST",
+		url,
+		nil,
+		common.SetUseBody(requestBody),
+		&requestBody,
+		&callResult,
+		common.MediumDuration,
+	)
+}
+
+// establishCrossCspPeering provisions a site-to-site IPsec tunnel between two vNets on different
+// CSPs, using the existing Spider primitives for public IPs, VPN gateways, and BGP/static routes
+// on both sides.
+func establishCrossCspPeering(vNetA model.TbVNetInfo, vNetB model.TbVNetInfo, info *model.TbVNetPeeringInfo) error {
+	client := resty.New()
+
+	sideA, err := provisionVpnGateway(client, vNetA, info.Name+"-a")
+	if err != nil {
+		return fmt.Errorf("failed to provision VPN gateway on %s: %w", vNetA.ConnectionName, err)
+	}
+	sideB, err := provisionVpnGateway(client, vNetB, info.Name+"-b")
+	if err != nil {
+		return fmt.Errorf("failed to provision VPN gateway on %s: %w", vNetB.ConnectionName, err)
+	}
+
+	if err := connectVpnTunnel(client, sideA, sideB); err != nil {
+		return fmt.Errorf("failed to connect IPsec tunnel: %w", err)
+	}
+
+	info.KeyValueList = append(info.KeyValueList,
+		common.KeyValue{Key: "vpnGatewayA", Value: sideA},
+		common.KeyValue{Key: "vpnGatewayB", Value: sideB},
+	)
+	return nil
+}
+
+// vpnGatewaySide is the Spider-assigned name of a provisioned VPN gateway, used to wire up the
+// tunnel between the two sides.
+type vpnGatewaySide = string
+
+// provisionVpnGateway creates a public IP and VPN gateway for vNet via CB-Spider, returning the
+// gateway's CSP-assigned name.
+func provisionVpnGateway(client *resty.Client, vNet model.TbVNetInfo, name string) (vpnGatewaySide, error) {
+	requestBody := struct {
+		ConnectionName string
+		Name           string
+		VPCName        string
+	}{
+		ConnectionName: vNet.ConnectionName,
+		Name:           name,
+		VPCName:        vNet.CspVNetName,
+	}
+
+	var callResult model.SpiderVPCInfo
+	url := fmt.Sprintf("%s/vpngateway", model.SpiderRestUrl)
+	err := common.ExecuteHttpRequest(
+		client,
+		"POST",
+		url,
+		nil,
+		common.SetUseBody(requestBody),
+		&requestBody,
+		&callResult,
+		common.MediumDuration,
+	)
+	if err != nil {
+		return "", err
+	}
+	return callResult.IId.NameId, nil
+}
+
+// connectVpnTunnel wires up the BGP/static routes between two already-provisioned VPN gateways.
+func connectVpnTunnel(client *resty.Client, gatewayA vpnGatewaySide, gatewayB vpnGatewaySide) error {
+	requestBody := struct {
+		GatewayNameA string
+		GatewayNameB string
+	}{GatewayNameA: gatewayA, GatewayNameB: gatewayB}
+
+	var callResult interface{}
+	url := fmt.Sprintf("%s/vpntunnel", model.SpiderRestUrl)
+	return common.ExecuteHttpRequest(
+		client,
+		"POST",
+		url,
+		nil,
+		common.SetUseBody(requestBody),
+		&requestBody,
+		&callResult,
+		common.MediumDuration,
+	)
+}
+
+// addPeeringAssociation records peeringKey in vNetId's AssociatedObjectList so DeleteVNet can
+// detect and refuse to remove a vNet that is still peered.
+func addPeeringAssociation(nsId string, vNetId string, peeringKey string) error {
+	Key := common.GenResourceKey(nsId, model.StrVNet, vNetId)
+	keyValue, err := kvstore.GetKv(Key)
+	if err != nil {
+		return err
+	}
+	if keyValue == nil {
+		return fmt.Errorf("vNet %s does not exist in namespace %s", vNetId, nsId)
+	}
+
+	vNetInfo := model.TbVNetInfo{}
+	if err := json.Unmarshal([]byte(keyValue.Value), &vNetInfo); err != nil {
+		return err
+	}
+
+	vNetInfo.AssociatedObjectList = append(vNetInfo.AssociatedObjectList, peeringKey)
+	val, err := json.Marshal(vNetInfo)
+	if err != nil {
+		return err
+	}
+	return kvstore.Put(Key, string(val))
+}
+
+// removePeeringAssociation drops peeringKey from vNetId's AssociatedObjectList, the inverse of
+// addPeeringAssociation. A vNet that never had the key associated (e.g. a failed peering) is left
+// untouched.
+func removePeeringAssociation(nsId string, vNetId string, peeringKey string) error {
+	Key := common.GenResourceKey(nsId, model.StrVNet, vNetId)
+	keyValue, err := kvstore.GetKv(Key)
+	if err != nil {
+		return err
+	}
+	if keyValue == nil {
+		return fmt.Errorf("vNet %s does not exist in namespace %s", vNetId, nsId)
+	}
+
+	vNetInfo := model.TbVNetInfo{}
+	if err := json.Unmarshal([]byte(keyValue.Value), &vNetInfo); err != nil {
+		return err
+	}
+
+	vNetInfo.AssociatedObjectList = removeString(vNetInfo.AssociatedObjectList, peeringKey)
+	val, err := json.Marshal(vNetInfo)
+	if err != nil {
+		return err
+	}
+	return kvstore.Put(Key, string(val))
+}
+
+// DeleteVNetPeering removes a TbVNetPeering record: it drops the peering key from both vNets'
+// AssociatedObjectList (so DeleteVNet is no longer blocked by it) and then deletes the KV store
+// and infostore records. This is the only way to clear a peering stuck in model.PeeringStatusFailed,
+// since CreateVNetPeering does not retry a failed attempt on its own.
+func DeleteVNetPeering(nsId string, peeringId string) error {
+	log.Info().Msg("DeleteVNetPeering")
+
+	err := common.CheckString(nsId)
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		return err
+	}
+
+	Key := common.GenResourceKey(nsId, model.StrVNetPeering, peeringId)
+	keyValue, err := kvstore.GetKv(Key)
+	if err != nil {
+		log.Error().Err(err).Msg("")
+		return err
+	}
+	if keyValue == nil {
+		return fmt.Errorf("vNet peering %s does not exist in namespace %s", peeringId, nsId)
+	}
+
+	info := model.TbVNetPeeringInfo{}
+	if err := json.Unmarshal([]byte(keyValue.Value), &info); err != nil {
+		log.Error().Err(err).Msg("")
+		return err
+	}
+
+	if info.Status != model.PeeringStatusFailed {
+		if linkErr := removePeeringAssociation(nsId, info.VNetIdA, Key); linkErr != nil {
+			log.Error().Err(linkErr).Msg("")
+		}
+		if linkErr := removePeeringAssociation(nsId, info.VNetIdB, Key); linkErr != nil {
+			log.Error().Err(linkErr).Msg("")
+		}
+	}
+
+	if err := kvstore.Delete(Key); err != nil {
+		log.Error().Err(err).Msg("")
+		return err
+	}
+
+	if err := infostore.DeleteVNetPeering(nsId, peeringId); err != nil {
+		log.Error().Err(err).Msg("infostore: failed to delete vNet peering record")
+	}
+
+	return nil
+}
+
+// hasActivePeering reports whether vNetId (in nsId) is referenced by any TbVNetPeering entry in
+// its AssociatedObjectList. DeleteVNet calls this to block deletion while peerings exist.
+func hasActivePeering(nsId string, vNetId string) bool {
+	Key := common.GenResourceKey(nsId, model.StrVNet, vNetId)
+	keyValue, err := kvstore.GetKv(Key)
+	if err != nil || keyValue == nil {
+		return false
+	}
+
+	vNetInfo := model.TbVNetInfo{}
+	if err := json.Unmarshal([]byte(keyValue.Value), &vNetInfo); err != nil {
+		return false
+	}
+
+	peeringKeyPrefix := common.GenResourceKey(nsId, model.StrVNetPeering, "")
+	for _, assocKey := range vNetInfo.AssociatedObjectList {
+		if strings.HasPrefix(assocKey, peeringKeyPrefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2019 The Cloud-Barista Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import "testing"
+
+func TestCheckCidrNonOverlap(t *testing.T) {
+	tests := []struct {
+		name    string
+		cidrA   string
+		cidrB   string
+		wantErr bool
+	}{
+		{"disjoint CIDRs", "10.0.0.0/24", "10.0.1.0/24", false},
+		{"identical CIDRs overlap", "10.0.0.0/24", "10.0.0.0/24", true},
+		{"B contained within A", "10.0.0.0/16", "10.0.1.0/24", true},
+		{"A contained within B", "10.0.1.0/24", "10.0.0.0/16", true},
+		{"invalid CIDR A", "not-a-cidr", "10.0.1.0/24", true},
+		{"invalid CIDR B", "10.0.0.0/24", "not-a-cidr", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkCidrNonOverlap(tt.cidrA, tt.cidrB)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkCidrNonOverlap(%q, %q) error = %v, wantErr %v", tt.cidrA, tt.cidrB, err, tt.wantErr)
+			}
+		})
+	}
+}